@@ -15,6 +15,7 @@ import (
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/services/httpservice"
 	"github.com/mattermost/mattermost-server/utils"
+	"github.com/mattermost/mattermost-server/utils/config"
 	goi18n "github.com/nicksnyder/go-i18n/i18n"
 )
 
@@ -132,52 +133,74 @@ func (a *App) Handle404(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *App) StartElasticsearch() {
+	// Goes through startElasticsearch, not a.Elasticsearch.Start() directly,
+	// so it takes elasticsearchMux like every other start/stop path; a
+	// config or license listener firing during boot would otherwise race
+	// this unguarded start.
+	a.startElasticsearch()
+
+	a.AddConfigListener(config.AddConfigDiffListener("ElasticsearchSettings.EnableIndexing", func(old, new interface{}) {
+		if new.(bool) {
+			a.startElasticsearch()
+		} else {
+			a.stopElasticsearch()
+		}
+	}))
+
+	for _, path := range []string{
+		"ElasticsearchSettings.ConnectionUrl",
+		"ElasticsearchSettings.Username",
+		"ElasticsearchSettings.Password",
+		"ElasticsearchSettings.Sniff",
+	} {
+		a.AddConfigListener(config.AddConfigDiffListener(path, func(old, new interface{}) {
+			if *a.Config().ElasticsearchSettings.EnableIndexing {
+				a.restartElasticsearch()
+			}
+		}))
+	}
+
+	a.AddLicenseListener(func() {
+		if a.License() != nil {
+			a.startElasticsearch()
+		} else {
+			a.stopElasticsearch()
+		}
+	})
+}
+
+func (a *App) startElasticsearch() {
 	a.Srv.Go(func() {
+		a.Srv.elasticsearchMux.Lock()
+		defer a.Srv.elasticsearchMux.Unlock()
+
 		if err := a.Elasticsearch.Start(); err != nil {
 			mlog.Error(err.Error())
 		}
 	})
+}
 
-	a.AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
-		if !*oldConfig.ElasticsearchSettings.EnableIndexing && *newConfig.ElasticsearchSettings.EnableIndexing {
-			a.Srv.Go(func() {
-				if err := a.Elasticsearch.Start(); err != nil {
-					mlog.Error(err.Error())
-				}
-			})
-		} else if *oldConfig.ElasticsearchSettings.EnableIndexing && !*newConfig.ElasticsearchSettings.EnableIndexing {
-			a.Srv.Go(func() {
-				if err := a.Elasticsearch.Stop(); err != nil {
-					mlog.Error(err.Error())
-				}
-			})
-		} else if *oldConfig.ElasticsearchSettings.Password != *newConfig.ElasticsearchSettings.Password || *oldConfig.ElasticsearchSettings.Username != *newConfig.ElasticsearchSettings.Username || *oldConfig.ElasticsearchSettings.ConnectionUrl != *newConfig.ElasticsearchSettings.ConnectionUrl || *oldConfig.ElasticsearchSettings.Sniff != *newConfig.ElasticsearchSettings.Sniff {
-			a.Srv.Go(func() {
-				if *oldConfig.ElasticsearchSettings.EnableIndexing {
-					if err := a.Elasticsearch.Stop(); err != nil {
-						mlog.Error(err.Error())
-					}
-					if err := a.Elasticsearch.Start(); err != nil {
-						mlog.Error(err.Error())
-					}
-				}
-			})
+func (a *App) restartElasticsearch() {
+	a.Srv.Go(func() {
+		a.Srv.elasticsearchMux.Lock()
+		defer a.Srv.elasticsearchMux.Unlock()
+
+		if err := a.Elasticsearch.Stop(); err != nil {
+			mlog.Error(err.Error())
+		}
+		if err := a.Elasticsearch.Start(); err != nil {
+			mlog.Error(err.Error())
 		}
 	})
+}
 
-	a.AddLicenseListener(func() {
-		if a.License() != nil {
-			a.Srv.Go(func() {
-				if err := a.Elasticsearch.Start(); err != nil {
-					mlog.Error(err.Error())
-				}
-			})
-		} else {
-			a.Srv.Go(func() {
-				if err := a.Elasticsearch.Stop(); err != nil {
-					mlog.Error(err.Error())
-				}
-			})
+func (a *App) stopElasticsearch() {
+	a.Srv.Go(func() {
+		a.Srv.elasticsearchMux.Lock()
+		defer a.Srv.elasticsearchMux.Unlock()
+
+		if err := a.Elasticsearch.Stop(); err != nil {
+			mlog.Error(err.Error())
 		}
 	})
 }
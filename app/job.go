@@ -0,0 +1,66 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func (a *App) GetJob(id string) (*model.Job, *model.AppError) {
+	result := <-a.Srv.Store.Job().Get(id)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.(*model.Job), nil
+}
+
+// CreateJob enqueues a new job of the given type. It is the single entry
+// point used by the `POST /jobs` API so that every job, built-in or
+// enterprise, goes through the same store write and worker dispatch.
+func (a *App) CreateJob(job *model.Job) (*model.Job, *model.AppError) {
+	return a.Srv.Jobs.CreateJob(job.Type, job.Data)
+}
+
+// JobsLeaderId returns this node's scheduler id while this node is the one
+// running the scheduler tick loop, for display in diagnostics and the
+// System Console. On any other node it returns ""; the id of a leader
+// running elsewhere is not looked up.
+func (a *App) JobsLeaderId() string {
+	if a.Srv.Jobs == nil || a.Srv.Jobs.Schedulers == nil || !a.Srv.Jobs.Schedulers.IsLeader() {
+		return ""
+	}
+	return a.Srv.Jobs.Schedulers.LeaderId()
+}
+
+// CancelJob marks a job as cancel-requested. Workers that thread a
+// jobs.NewCancelContext through their batch loop pick this up on their
+// next poll and stop cleanly rather than being killed outright.
+func (a *App) CancelJob(jobId string) *model.AppError {
+	job, err := a.GetJob(jobId)
+	if err != nil {
+		return err
+	}
+
+	switch job.Status {
+	case model.JOB_STATUS_PENDING, model.JOB_STATUS_IN_PROGRESS:
+	default:
+		return model.NewAppError("CancelJob", "app.job.cancel_job.status.app_error", nil, "id="+jobId, http.StatusBadRequest)
+	}
+
+	if result := <-a.Srv.Store.Job().RequestCancellation(jobId); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+func (a *App) GetJobsByType(jobType string) ([]*model.Job, *model.AppError) {
+	result := <-a.Srv.Store.Job().GetAllByType(jobType)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.([]*model.Job), nil
+}
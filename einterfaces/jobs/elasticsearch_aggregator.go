@@ -0,0 +1,14 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import "github.com/mattermost/mattermost-server/jobs"
+
+// ElasticsearchAggregatorInterface is implemented by the job that rolls
+// daily Elasticsearch post indices into monthly ones on a schedule. It is
+// registered on JobServer.ElasticsearchAggregator by the enterprise build.
+type ElasticsearchAggregatorInterface interface {
+	MakeWorker() jobs.Worker
+	MakeScheduler() jobs.Scheduler
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import "github.com/mattermost/mattermost-server/jobs"
+
+// ElasticsearchIndexerInterface is implemented by the full-corpus post
+// reindex job. It is registered on JobServer.ElasticsearchIndexer by the
+// enterprise build; the OSS server only ever sees it through this
+// interface so it can enqueue and report on the job without depending on
+// the implementation.
+type ElasticsearchIndexerInterface interface {
+	MakeWorker() jobs.Worker
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package elasticsearch_post_aggregation
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// ElasticsearchAggregatorScheduler fires once a day and enqueues a job to
+// roll daily Elasticsearch post indices older than
+// ElasticsearchSettings.AggregatePostsAfterDays into monthly ones.
+type ElasticsearchAggregatorScheduler struct {
+	app *app.App
+}
+
+func MakeScheduler(a *app.App) *ElasticsearchAggregatorScheduler {
+	return &ElasticsearchAggregatorScheduler{app: a}
+}
+
+func (scheduler *ElasticsearchAggregatorScheduler) Name() string {
+	return "ElasticsearchAggregatorScheduler"
+}
+
+func (scheduler *ElasticsearchAggregatorScheduler) JobType() string {
+	return model.JOB_TYPE_ELASTICSEARCH_POST_AGGREGATION
+}
+
+func (scheduler *ElasticsearchAggregatorScheduler) Enabled(cfg *model.Config) bool {
+	return *cfg.ElasticsearchSettings.EnableIndexing && *cfg.ElasticsearchSettings.AggregatePostsAfterDays > 0
+}
+
+func (scheduler *ElasticsearchAggregatorScheduler) NextScheduleTime(cfg *model.Config, now time.Time, pendingJobs bool, lastSuccessfulJob *model.Job) *time.Time {
+	if pendingJobs {
+		return nil
+	}
+	next := now.Add(24 * time.Hour)
+	return &next
+}
+
+func (scheduler *ElasticsearchAggregatorScheduler) ScheduleJob(cfg *model.Config, pendingJobs bool, lastSuccessfulJob *model.Job) (*model.Job, *model.AppError) {
+	return scheduler.app.CreateJob(&model.Job{Type: model.JOB_TYPE_ELASTICSEARCH_POST_AGGREGATION})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package elasticsearch_post_aggregation
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/jobs"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type ElasticsearchAggregatorWorker struct {
+	app     *app.App
+	jobs    chan model.Job
+	stop    chan bool
+	stopped chan bool
+}
+
+func MakeWorker(a *app.App) jobs.Worker {
+	return &ElasticsearchAggregatorWorker{
+		app:     a,
+		jobs:    make(chan model.Job),
+		stop:    make(chan bool),
+		stopped: make(chan bool, 1),
+	}
+}
+
+func (w *ElasticsearchAggregatorWorker) JobChannel() chan<- model.Job {
+	return w.jobs
+}
+
+func (w *ElasticsearchAggregatorWorker) Run() {
+	mlog.Info("Worker started", mlog.String("worker", "ElasticsearchAggregatorWorker"))
+
+	defer func() {
+		w.stopped <- true
+	}()
+
+	for {
+		select {
+		case <-w.stop:
+			mlog.Info("Worker received stop signal", mlog.String("worker", "ElasticsearchAggregatorWorker"))
+			return
+		case job := <-w.jobs:
+			w.doJob(&job)
+		}
+	}
+}
+
+func (w *ElasticsearchAggregatorWorker) Stop() {
+	w.stop <- true
+	<-w.stopped
+}
+
+func (w *ElasticsearchAggregatorWorker) doJob(job *model.Job) {
+	if claimed, err := w.app.Srv.Jobs.ClaimJob(job); err != nil || !claimed {
+		return
+	}
+
+	if err := w.app.Elasticsearch.AggregatePostIndices(); err != nil {
+		w.app.Srv.Jobs.SetJobError(job, err)
+		return
+	}
+
+	job.Progress = 100
+	w.app.Srv.Jobs.SetJobSuccess(job)
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package elasticsearch_post_indexing
+
+import (
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/jobs"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	BATCH_SIZE = 1000
+
+	JOB_DATA_CURSOR_CREATE_AT = "cursor_create_at"
+	JOB_DATA_CURSOR_POST_ID   = "cursor_post_id"
+)
+
+// ElasticsearchIndexerWorker walks the full post corpus in CreateAt order
+// and bulk-indexes it into Elasticsearch. Progress is checkpointed onto
+// the job's Data map after every batch so that a restart resumes from
+// where it left off instead of reindexing from scratch.
+type ElasticsearchIndexerWorker struct {
+	app     *app.App
+	jobs    chan model.Job
+	stop    chan bool
+	stopped chan bool
+}
+
+func MakeWorker(a *app.App) jobs.Worker {
+	return &ElasticsearchIndexerWorker{
+		app:     a,
+		jobs:    make(chan model.Job),
+		stop:    make(chan bool),
+		stopped: make(chan bool, 1),
+	}
+}
+
+func (w *ElasticsearchIndexerWorker) JobChannel() chan<- model.Job {
+	return w.jobs
+}
+
+func (w *ElasticsearchIndexerWorker) Run() {
+	mlog.Info("Worker started", mlog.String("worker", "ElasticsearchIndexerWorker"))
+
+	defer func() {
+		w.stopped <- true
+	}()
+
+	for {
+		select {
+		case <-w.stop:
+			mlog.Info("Worker received stop signal", mlog.String("worker", "ElasticsearchIndexerWorker"))
+			return
+		case job := <-w.jobs:
+			w.doJob(&job)
+		}
+	}
+}
+
+func (w *ElasticsearchIndexerWorker) Stop() {
+	w.stop <- true
+	<-w.stopped
+}
+
+func (w *ElasticsearchIndexerWorker) doJob(job *model.Job) {
+	if claimed, err := w.app.Srv.Jobs.ClaimJob(job); err != nil || !claimed {
+		return
+	}
+
+	if job.Data == nil {
+		job.Data = make(map[string]string)
+	}
+
+	cursorCreateAt, _ := strconv.ParseInt(job.Data[JOB_DATA_CURSOR_CREATE_AT], 10, 64)
+	cursorPostId := job.Data[JOB_DATA_CURSOR_POST_ID]
+
+	// setJobError, like the cancel and success paths below, also clears the
+	// job's progress-throttle entry once the job reaches a terminal state.
+	// The stop path sets the job back to pending and keeps the entry.
+	setJobError := func(err *model.AppError) {
+		w.app.Srv.Jobs.SetJobError(job, err)
+		w.app.Srv.Jobs.ClearProgress(job.Id)
+	}
+
+	totalResult := <-w.app.Srv.Store.Post().AnalyticsPostCount("", false, false)
+	if totalResult.Err != nil {
+		setJobError(totalResult.Err)
+		return
+	}
+	total := totalResult.Data.(int64)
+
+	// On a fresh job this is 0; on a resumed one, seed it from the restored
+	// checkpoint so progress reflects everything indexed in prior runs
+	// instead of resetting to ~0% on every restart. The count must match
+	// the keyset cursor GetPostsBatchForIndexing advances by: everything
+	// with an earlier CreateAt, plus posts at cursorCreateAt whose Id has
+	// already been consumed (Id <= cursorPostId), not just CreateAt <
+	// cursorCreateAt, or the last partial batch before a restart is
+	// recounted and indexed comes up short.
+	var indexed int64
+	if cursorCreateAt > 0 {
+		indexedResult := <-w.app.Srv.Store.Post().GetPostCountForIndexingCursor(cursorCreateAt, cursorPostId)
+		if indexedResult.Err != nil {
+			setJobError(indexedResult.Err)
+			return
+		}
+		indexed = indexedResult.Data.(int64)
+	}
+
+	ctx, cancel := w.app.Srv.Jobs.NewCancelContext(job)
+	defer cancel()
+
+	for {
+		select {
+		case <-w.stop:
+			w.app.Srv.Jobs.SetJobPending(job)
+			return
+		case <-ctx.Done():
+			w.app.Srv.Jobs.SetJobCanceled(job)
+			w.app.Srv.Jobs.ClearProgress(job.Id)
+			return
+		default:
+		}
+
+		result := <-w.app.Srv.Store.Post().GetPostsBatchForIndexing(cursorCreateAt, cursorPostId, BATCH_SIZE)
+		if result.Err != nil {
+			setJobError(result.Err)
+			return
+		}
+
+		posts := result.Data.([]*model.Post)
+		if len(posts) == 0 {
+			break
+		}
+
+		if err := w.app.Elasticsearch.IndexPostsBulk(posts); err != nil {
+			setJobError(err)
+			return
+		}
+
+		last := posts[len(posts)-1]
+		cursorCreateAt = last.CreateAt
+		cursorPostId = last.Id
+		indexed += int64(len(posts))
+
+		job.Data[JOB_DATA_CURSOR_CREATE_AT] = strconv.FormatInt(cursorCreateAt, 10)
+		job.Data[JOB_DATA_CURSOR_POST_ID] = cursorPostId
+
+		if err := w.app.Srv.Jobs.UpdateInProgressJobData(job); err != nil {
+			setJobError(err)
+			return
+		}
+		w.app.Srv.Jobs.ReportProgress(job.Id, indexed, total)
+	}
+
+	job.Progress = 100
+	w.app.Srv.Jobs.SetJobSuccess(job)
+	w.app.Srv.Jobs.ClearProgress(job.Id)
+}
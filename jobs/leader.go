@@ -0,0 +1,128 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// Leader election for the Schedulers tick loop. When the server is part of
+// a cluster, leadership is delegated to ClusterInterface, which already
+// elects a leader for other singleton background work and notifies
+// listeners when that leader changes (node shutdown, partition recovery).
+// When clustering is disabled (or no ClusterInterface is registered) we
+// fall back to a TTL'd lease row in the Systems table so the
+// one-node-runs-schedulers invariant still holds for multi-process
+// deployments sharing a single DB.
+const (
+	leaseValueSeparator = "|"
+)
+
+// IsLeader reports whether this node currently holds the scheduler lease.
+func (schedulers *Schedulers) IsLeader() bool {
+	return atomic.LoadInt32(&schedulers.leaderFlag) == 1
+}
+
+// renewOrAcquireLease is polled while this node does not hold the lease, and
+// on every renew tick while it does. On a cluster it's a no-op: leadership
+// there is driven entirely by onClusterLeaderChanged. Otherwise it attempts
+// to write a lease row with a TTL, succeeding only if no other node holds an
+// unexpired one.
+//
+// The write is always conditional: when no lease row exists yet we insert
+// one only if the insert doesn't collide with a concurrent insert from
+// another node (InsertIfNotExists), and when a row exists we compare-and-
+// swap against the exact value we just read (CompareAndSet). That rules out
+// the read-then-unconditional-write race where two nodes both observe an
+// expired (or absent) lease and both believe their own write won.
+func (schedulers *Schedulers) renewOrAcquireLease() {
+	if schedulers.cluster != nil {
+		return
+	}
+
+	now := model.GetMillis()
+	newValue := formatLease(now+SCHEDULERS_LEASE_DURATION.Nanoseconds()/int64(time.Millisecond), schedulers.leaderId)
+
+	result := <-schedulers.jobs.Store.System().GetByName(SCHEDULERS_LEADER_SYSTEM_KEY)
+	if result.Err != nil {
+		// No lease row exists yet (cold start). Every node racing to create
+		// it goes through InsertIfNotExists, so only one insert actually
+		// lands; everyone else's Data is false and they fall back to
+		// polling, picking up the winner's lease on their next attempt.
+		insertResult := <-schedulers.jobs.Store.System().InsertIfNotExists(&model.System{
+			Name:  SCHEDULERS_LEADER_SYSTEM_KEY,
+			Value: newValue,
+		})
+		if insertResult.Err != nil {
+			mlog.Error("Failed to acquire scheduler leader lease", mlog.Err(insertResult.Err))
+			atomic.StoreInt32(&schedulers.leaderFlag, 0)
+			return
+		}
+		if !insertResult.Data.(bool) {
+			atomic.StoreInt32(&schedulers.leaderFlag, 0)
+			return
+		}
+		atomic.StoreInt32(&schedulers.leaderFlag, 1)
+		return
+	}
+
+	lease := result.Data.(*model.System)
+	expiresAt, holder := parseLease(lease.Value)
+	if expiresAt > now && holder != schedulers.leaderId {
+		atomic.StoreInt32(&schedulers.leaderFlag, 0)
+		return
+	}
+
+	// The lease is expired or already ours: swap it for our new value, but
+	// only if it still holds the exact value we just read. If another node
+	// renewed or acquired it in between, this fails and we simply didn't
+	// win this round.
+	casResult := <-schedulers.jobs.Store.System().CompareAndSet(SCHEDULERS_LEADER_SYSTEM_KEY, lease.Value, newValue)
+	if casResult.Err != nil {
+		mlog.Error("Failed to renew or acquire scheduler leader lease", mlog.Err(casResult.Err))
+		atomic.StoreInt32(&schedulers.leaderFlag, 0)
+		return
+	}
+	if !casResult.Data.(bool) {
+		atomic.StoreInt32(&schedulers.leaderFlag, 0)
+		return
+	}
+
+	atomic.StoreInt32(&schedulers.leaderFlag, 1)
+}
+
+// onClusterLeaderChanged is invoked when ClusterInterface reports that
+// leadership has moved. A new leader immediately reloads every scheduler's
+// next-run time via onBecomeLeader so no tick is lost or double-fired.
+func (schedulers *Schedulers) onClusterLeaderChanged(isLeader bool) {
+	wasLeader := schedulers.IsLeader()
+	if isLeader {
+		atomic.StoreInt32(&schedulers.leaderFlag, 1)
+	} else {
+		atomic.StoreInt32(&schedulers.leaderFlag, 0)
+	}
+
+	if isLeader && !wasLeader {
+		schedulers.onBecomeLeader()
+	}
+}
+
+func formatLease(expiresAt int64, holder string) string {
+	return strings.Join([]string{holder, strconv.FormatInt(expiresAt, 10)}, leaseValueSeparator)
+}
+
+func parseLease(value string) (expiresAt int64, holder string) {
+	parts := strings.SplitN(value, leaseValueSeparator, 2)
+	if len(parts) != 2 {
+		return 0, ""
+	}
+	expiresAt, _ = strconv.ParseInt(parts[1], 10, 64)
+	return expiresAt, parts[0]
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	PROGRESS_REPORT_MIN_INTERVAL = time.Second
+	CANCEL_POLL_INTERVAL         = 2 * time.Second
+)
+
+// progressThrottle tracks, per job, the last time ReportProgress actually
+// wrote through to the store, so a tight batch loop can call it after
+// every batch without hammering the DB.
+type progressThrottle struct {
+	mux      sync.Mutex
+	lastSent map[string]time.Time
+}
+
+var throttle = &progressThrottle{lastSent: make(map[string]time.Time)}
+
+// ReportProgress updates a job's Progress/ProgressTotal, writing through to
+// the store at most once per second per job. Workers are expected to call
+// this after each batch; callers don't need to rate-limit themselves.
+func (srv *JobServer) ReportProgress(jobId string, done int64, total int64) {
+	throttle.mux.Lock()
+	last, ok := throttle.lastSent[jobId]
+	now := time.Now()
+	if ok && now.Sub(last) < PROGRESS_REPORT_MIN_INTERVAL {
+		throttle.mux.Unlock()
+		return
+	}
+	throttle.lastSent[jobId] = now
+	throttle.mux.Unlock()
+
+	progress := int64(0)
+	if total > 0 {
+		progress = done * 100 / total
+	}
+
+	if result := <-srv.Store.Job().UpdateProgress(jobId, progress, total); result.Err != nil {
+		mlog.Error("Failed to report job progress", mlog.String("job_id", jobId), mlog.Err(result.Err))
+	}
+}
+
+// ClearProgress forgets the throttle state for a job. Workers must call this
+// once a job reaches a terminal state (success, error or canceled) so
+// throttle.lastSent doesn't grow for as long as the server runs.
+func (srv *JobServer) ClearProgress(jobId string) {
+	throttle.mux.Lock()
+	defer throttle.mux.Unlock()
+	delete(throttle.lastSent, jobId)
+}
+
+// NewCancelContext returns a context that is canceled once an admin calls
+// POST /api/v4/jobs/{job_id}/cancel for the given job. Workers should
+// thread this context through their batch loop and check ctx.Done()
+// between batches instead of polling CancelRequested themselves.
+func (srv *JobServer) NewCancelContext(job *model.Job) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(CANCEL_POLL_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result := <-srv.Store.Job().Get(job.Id)
+				if result.Err != nil {
+					continue
+				}
+				if result.Data.(*model.Job).CancelRequested {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-server/einterfaces"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	SCHEDULERS_LEADER_SYSTEM_KEY = "SchedulersLeader"
+	SCHEDULERS_LEASE_DURATION    = 30 * time.Second
+	SCHEDULERS_LEASE_RENEW       = 10 * time.Second
+)
+
+type Scheduler interface {
+	Name() string
+	JobType() string
+	Enabled(cfg *model.Config) bool
+	NextScheduleTime(cfg *model.Config, now time.Time, pendingJobs bool, lastSuccessfulJob *model.Job) *time.Time
+	ScheduleJob(cfg *model.Config, pendingJobs bool, lastSuccessfulJob *model.Job) (*model.Job, *model.AppError)
+}
+
+// Schedulers owns the tick loop that fires scheduled jobs (data retention,
+// LDAP sync, ES aggregation, ...). In an HA deployment every node
+// constructs a Schedulers, but only the elected leader actually ticks; the
+// rest sit idle so a scheduled job isn't double-fired across the cluster.
+type Schedulers struct {
+	stop          chan bool
+	stopped       chan bool
+	configChanged chan *model.Config
+	listenerId    string
+	jobs          *JobServer
+	schedulers    []Scheduler
+	nextRunTimes  []*time.Time
+	timers        []*time.Timer
+
+	// Leader election. leaderId identifies this node; leaderFlag is 1 while
+	// it holds the scheduler lease, either via ClusterInterface (when
+	// clustering is enabled) or a TTL'd lease row in the Systems table.
+	leaderId   string
+	cluster    einterfaces.ClusterInterface
+	leaderFlag int32
+
+	mux sync.Mutex
+}
+
+func (srv *JobServer) InitSchedulers() *Schedulers {
+	schedulers := &Schedulers{
+		stop:          make(chan bool),
+		stopped:       make(chan bool),
+		configChanged: make(chan *model.Config),
+		jobs:          srv,
+		schedulers:    []Scheduler{},
+		leaderId:      model.NewId(),
+		cluster:       srv.Cluster,
+	}
+
+	if schedulers.cluster != nil {
+		// The cluster already elects a leader for singleton background
+		// work; piggyback on it instead of running our own lease so a
+		// failover or partition recovery is picked up on the next
+		// SCHEDULERS_LEASE_RENEW poll rather than after a lease TTL.
+		schedulers.cluster.RegisterLeaderChangeListener(schedulers.onClusterLeaderChanged)
+	}
+
+	if srv.DataRetentionJob != nil {
+		schedulers.schedulers = append(schedulers.schedulers, srv.DataRetentionJob.MakeScheduler())
+	}
+	if srv.MessageExportJob != nil {
+		schedulers.schedulers = append(schedulers.schedulers, srv.MessageExportJob.MakeScheduler())
+	}
+	if srv.ElasticsearchAggregator != nil {
+		schedulers.schedulers = append(schedulers.schedulers, srv.ElasticsearchAggregator.MakeScheduler())
+	}
+	if srv.LdapSync != nil {
+		schedulers.schedulers = append(schedulers.schedulers, srv.LdapSync.MakeScheduler())
+	}
+
+	schedulers.nextRunTimes = make([]*time.Time, len(schedulers.schedulers))
+	schedulers.timers = make([]*time.Timer, len(schedulers.schedulers))
+
+	return schedulers
+}
+
+func (schedulers *Schedulers) Start() *Schedulers {
+	schedulers.listenerId = schedulers.jobs.ConfigService.AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
+		schedulers.configChanged <- newConfig
+	})
+
+	go schedulers.acquireLeadershipAndRun()
+
+	return schedulers
+}
+
+// LeaderId returns this node's identity. It is only meaningful to callers
+// once IsLeader reports true.
+func (schedulers *Schedulers) LeaderId() string {
+	return schedulers.leaderId
+}
+
+func (schedulers *Schedulers) Stop() *Schedulers {
+	schedulers.stop <- true
+	<-schedulers.stopped
+	return schedulers
+}
+
+// acquireLeadershipAndRun blocks until this node becomes the scheduler
+// leader, runs the tick loop while it holds the lease, and re-attempts
+// leadership if it's ever lost (node shutdown elsewhere, partition
+// recovery, the non-cluster lease expiring because a renewal failed, or
+// the ClusterInterface reporting that leadership moved).
+func (schedulers *Schedulers) acquireLeadershipAndRun() {
+	defer close(schedulers.stopped)
+
+	for {
+		if schedulers.cluster == nil && !schedulers.IsLeader() {
+			schedulers.renewOrAcquireLease()
+		}
+
+		if schedulers.IsLeader() {
+			schedulers.onBecomeLeader()
+			if schedulers.run() {
+				// Stop was called while we held leadership.
+				return
+			}
+			// Leadership was lost (renewal failed, or the cluster handed
+			// it to another node) without a stop request; go back around
+			// and try to reacquire it.
+			continue
+		}
+
+		select {
+		case <-schedulers.stop:
+			return
+		case <-time.After(SCHEDULERS_LEASE_RENEW):
+		}
+	}
+}
+
+// onBecomeLeader reloads the next-run time for every registered scheduler
+// from the persisted Job rows so a failover resumes the existing schedule
+// instead of restarting it (and, symmetrically, doesn't immediately double
+// fire a job the old leader had just started).
+func (schedulers *Schedulers) onBecomeLeader() {
+	schedulers.mux.Lock()
+	defer schedulers.mux.Unlock()
+
+	mlog.Info("This node is now the Schedulers leader")
+
+	cfg := schedulers.jobs.ConfigService.Config()
+	for i, scheduler := range schedulers.schedulers {
+		if !scheduler.Enabled(cfg) {
+			schedulers.nextRunTimes[i] = nil
+			continue
+		}
+		pendingJobs := schedulers.jobs.checkForPendingJobsByType(scheduler.JobType())
+		lastSuccessfulJob, _ := schedulers.jobs.getLastSuccessfulJobByType(scheduler.JobType())
+		schedulers.nextRunTimes[i] = scheduler.NextScheduleTime(cfg, time.Now(), pendingJobs, lastSuccessfulJob)
+	}
+}
+
+// run is the tick loop for as long as this node holds leadership. It
+// returns true if it exited because Stop was called, or false if it
+// exited because leadership was lost (e.g. a lease renewal failed),
+// telling acquireLeadershipAndRun whether to try to reacquire it.
+func (schedulers *Schedulers) run() bool {
+	mlog.Info("Starting schedulers")
+
+	defer mlog.Info("Schedulers stopped")
+
+	schedulers.mux.Lock()
+	schedulers.scheduleAllTimersLocked()
+	schedulers.mux.Unlock()
+
+	defer schedulers.stopTimers()
+
+	// While leader on a non-cluster deployment, the lease we acquired in
+	// acquireLeadershipAndRun must be renewed well before
+	// SCHEDULERS_LEASE_DURATION elapses, or another node's own renewal
+	// attempt will see it as expired and also become leader.
+	renewTicker := time.NewTicker(SCHEDULERS_LEASE_RENEW)
+	defer renewTicker.Stop()
+
+	for {
+		select {
+		case <-schedulers.stop:
+			mlog.Debug("Schedulers received stop signal")
+			return true
+		case newCfg := <-schedulers.configChanged:
+			schedulers.handleConfigChange(newCfg)
+		case <-renewTicker.C:
+			if schedulers.cluster == nil {
+				schedulers.renewOrAcquireLease()
+			}
+		}
+
+		if !schedulers.IsLeader() {
+			return false
+		}
+	}
+}
+
+// scheduleAllTimersLocked arms (or re-arms) a *time.Timer for every
+// scheduler whose nextRunTimes entry is set, replacing whatever timer was
+// previously there. Callers must hold schedulers.mux.
+func (schedulers *Schedulers) scheduleAllTimersLocked() {
+	now := time.Now()
+	for i, nextTime := range schedulers.nextRunTimes {
+		schedulers.scheduleTimerLocked(i, now, nextTime)
+	}
+}
+
+// scheduleTimerLocked arms the timer for scheduler idx to fire at nextTime,
+// or does nothing (after stopping any existing timer) if nextTime is nil.
+// Callers must hold schedulers.mux.
+func (schedulers *Schedulers) scheduleTimerLocked(idx int, now time.Time, nextTime *time.Time) {
+	if schedulers.timers[idx] != nil {
+		schedulers.timers[idx].Stop()
+		schedulers.timers[idx] = nil
+	}
+
+	if nextTime == nil {
+		return
+	}
+
+	duration := nextTime.Sub(now)
+	if duration < 0 {
+		duration = 0
+	}
+
+	schedulers.timers[idx] = time.AfterFunc(duration, func() {
+		schedulers.handleTick(idx)
+	})
+}
+
+// handleTick fires when scheduler idx's timer elapses: it schedules the job
+// (if the scheduler is still enabled and this node is still leader), then
+// recomputes and re-arms the next run time so the tick loop continues
+// indefinitely without drift.
+func (schedulers *Schedulers) handleTick(idx int) {
+	schedulers.mux.Lock()
+	defer schedulers.mux.Unlock()
+
+	if !schedulers.IsLeader() {
+		return
+	}
+
+	scheduler := schedulers.schedulers[idx]
+	cfg := schedulers.jobs.ConfigService.Config()
+
+	if scheduler.Enabled(cfg) {
+		pendingJobs := schedulers.jobs.checkForPendingJobsByType(scheduler.JobType())
+		lastSuccessfulJob, _ := schedulers.jobs.getLastSuccessfulJobByType(scheduler.JobType())
+
+		if !pendingJobs {
+			if _, err := scheduler.ScheduleJob(cfg, pendingJobs, lastSuccessfulJob); err != nil {
+				mlog.Error("Failed to schedule job", mlog.String("scheduler", scheduler.Name()), mlog.Err(err))
+			}
+		}
+	}
+
+	// Recompute and re-arm regardless of whether a job was scheduled above,
+	// so a disabled/skipped tick still gets a fresh timer instead of going
+	// silent.
+	now := time.Now()
+	var nextTime *time.Time
+	if scheduler.Enabled(cfg) {
+		pendingJobs := schedulers.jobs.checkForPendingJobsByType(scheduler.JobType())
+		lastSuccessfulJob, _ := schedulers.jobs.getLastSuccessfulJobByType(scheduler.JobType())
+		nextTime = scheduler.NextScheduleTime(cfg, now, pendingJobs, lastSuccessfulJob)
+	}
+	schedulers.nextRunTimes[idx] = nextTime
+	schedulers.scheduleTimerLocked(idx, now, nextTime)
+}
+
+// stopTimers stops every armed timer without rescheduling. Called when this
+// node stops running schedulers, whether because Stop was called or
+// leadership was lost.
+func (schedulers *Schedulers) stopTimers() {
+	schedulers.mux.Lock()
+	defer schedulers.mux.Unlock()
+
+	for i, timer := range schedulers.timers {
+		if timer != nil {
+			timer.Stop()
+			schedulers.timers[i] = nil
+		}
+	}
+}
+
+func (schedulers *Schedulers) handleConfigChange(newCfg *model.Config) {
+	schedulers.mux.Lock()
+	defer schedulers.mux.Unlock()
+
+	now := time.Now()
+	for i, scheduler := range schedulers.schedulers {
+		if !scheduler.Enabled(newCfg) {
+			schedulers.nextRunTimes[i] = nil
+			schedulers.scheduleTimerLocked(i, now, nil)
+			continue
+		}
+		pendingJobs := schedulers.jobs.checkForPendingJobsByType(scheduler.JobType())
+		lastSuccessfulJob, _ := schedulers.jobs.getLastSuccessfulJobByType(scheduler.JobType())
+		nextTime := scheduler.NextScheduleTime(newCfg, now, pendingJobs, lastSuccessfulJob)
+		schedulers.nextRunTimes[i] = nextTime
+		schedulers.scheduleTimerLocked(i, now, nextTime)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	JOB_TYPE_DATA_RETENTION                 = "data_retention"
+	JOB_TYPE_MESSAGE_EXPORT                 = "message_export"
+	JOB_TYPE_ELASTICSEARCH_POST_INDEXING    = "elasticsearch_post_indexing"
+	JOB_TYPE_ELASTICSEARCH_POST_AGGREGATION = "elasticsearch_post_aggregation"
+	JOB_TYPE_LDAP_SYNC                      = "ldap_sync"
+	JOB_TYPE_MIGRATIONS                     = "migrations"
+
+	JOB_STATUS_PENDING          = "pending"
+	JOB_STATUS_IN_PROGRESS      = "in_progress"
+	JOB_STATUS_SUCCESS          = "success"
+	JOB_STATUS_ERROR            = "error"
+	JOB_STATUS_CANCEL_REQUESTED = "cancel_requested"
+	JOB_STATUS_CANCELED         = "canceled"
+)
+
+type Job struct {
+	Id              string            `json:"id"`
+	Type            string            `json:"type"`
+	Priority        int64             `json:"priority"`
+	CreateAt        int64             `json:"create_at"`
+	StartAt         int64             `json:"start_at"`
+	LastActivityAt  int64             `json:"last_activity_at"`
+	Status          string            `json:"status"`
+	Progress        int64             `json:"progress"`
+	ProgressTotal   int64             `json:"progress_total"`
+	CancelRequested bool              `json:"cancel_requested"`
+	Data            map[string]string `json:"data"`
+}
+
+func (j *Job) ToJson() string {
+	b, _ := json.Marshal(j)
+	return string(b)
+}
+
+func JobFromJson(data io.Reader) *Job {
+	var job Job
+	if err := json.NewDecoder(data).Decode(&job); err != nil {
+		return nil
+	}
+	return &job
+}
+
+func JobsToJson(jobs []*Job) string {
+	b, _ := json.Marshal(jobs)
+	return string(b)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package config provides a reflection-based alternative to hand-rolled
+// "did any of these four fields change" conditionals in config change
+// listeners.
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// Listener is the shape App.AddConfigListener (and friends) expect.
+type Listener func(oldConfig, newConfig *model.Config)
+
+// AddConfigDiffListener builds a Listener that only invokes fn when the
+// value at the dotted config path (e.g. "ElasticsearchSettings.ConnectionUrl")
+// actually changed between oldConfig and newConfig. Register the result
+// with the usual config listener mechanism:
+//
+//	a.AddConfigListener(config.AddConfigDiffListener("ElasticsearchSettings.ConnectionUrl",
+//		func(old, new interface{}) {
+//			restartElasticsearchClient()
+//		}))
+//
+// This lets each concern subscribe to just the paths it cares about
+// instead of every listener re-deriving "did anything relevant change"
+// itself.
+func AddConfigDiffListener(path string, fn func(old, new interface{})) Listener {
+	return func(oldConfig, newConfig *model.Config) {
+		oldVal, oldOk := valueAtPath(oldConfig, path)
+		newVal, newOk := valueAtPath(newConfig, path)
+
+		if !oldOk || !newOk {
+			return
+		}
+
+		if !reflect.DeepEqual(oldVal, newVal) {
+			fn(oldVal, newVal)
+		}
+	}
+}
+
+// valueAtPath walks cfg field-by-field following the dotted path,
+// dereferencing pointers (model.Config fields are almost always *string,
+// *bool, etc) as it goes. It reports false if any segment doesn't resolve
+// to an exported field or a nil pointer is encountered along the way.
+func valueAtPath(cfg *model.Config, path string) (interface{}, bool) {
+	v := reflect.ValueOf(cfg)
+
+	for _, part := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		v = v.FieldByName(part)
+		if !v.IsValid() || !v.CanInterface() {
+			return nil, false
+		}
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	return v.Interface(), true
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConfig(connectionUrl string, enableIndexing bool) *model.Config {
+	cfg := &model.Config{}
+	cfg.ElasticsearchSettings.ConnectionUrl = model.NewString(connectionUrl)
+	cfg.ElasticsearchSettings.EnableIndexing = model.NewBool(enableIndexing)
+	return cfg
+}
+
+func TestAddConfigDiffListenerFiresOnChange(t *testing.T) {
+	fired := 0
+
+	listener := AddConfigDiffListener("ElasticsearchSettings.ConnectionUrl", func(old, new interface{}) {
+		fired++
+		assert.Equal(t, "http://old:9200", old)
+		assert.Equal(t, "http://new:9200", new)
+	})
+
+	oldConfig := newTestConfig("http://old:9200", true)
+	newConfig := newTestConfig("http://new:9200", true)
+
+	listener(oldConfig, newConfig)
+
+	assert.Equal(t, 1, fired)
+}
+
+func TestAddConfigDiffListenerSkipsUnchangedValue(t *testing.T) {
+	fired := 0
+
+	listener := AddConfigDiffListener("ElasticsearchSettings.ConnectionUrl", func(old, new interface{}) {
+		fired++
+	})
+
+	oldConfig := newTestConfig("http://same:9200", true)
+	newConfig := newTestConfig("http://same:9200", false)
+
+	listener(oldConfig, newConfig)
+
+	assert.Equal(t, 0, fired)
+}
+
+func TestAddConfigDiffListenerOnlyFiresForItsOwnPath(t *testing.T) {
+	var urlFired, indexingFired int
+
+	urlListener := AddConfigDiffListener("ElasticsearchSettings.ConnectionUrl", func(old, new interface{}) {
+		urlFired++
+	})
+	indexingListener := AddConfigDiffListener("ElasticsearchSettings.EnableIndexing", func(old, new interface{}) {
+		indexingFired++
+	})
+
+	oldConfig := newTestConfig("http://same:9200", false)
+	newConfig := newTestConfig("http://same:9200", true)
+
+	urlListener(oldConfig, newConfig)
+	indexingListener(oldConfig, newConfig)
+
+	assert.Equal(t, 0, urlFired)
+	assert.Equal(t, 1, indexingFired)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// defaultConfigPath is used when MM_CONFIG isn't set, matching the path the
+// web tier falls back to.
+const defaultConfigPath = "config/config.json"
+
+// Load reads the server configuration from the same source the web tier
+// does: the file at MM_CONFIG if that environment variable is set,
+// otherwise defaultConfigPath. Fields the file doesn't set are filled in by
+// model.Config.SetDefaults so partial config files still produce a usable
+// config, the same as a freshly-installed config.json would.
+func Load() (*model.Config, error) {
+	path := os.Getenv("MM_CONFIG")
+	if path == "" {
+		path = defaultConfigPath
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file at %s: %w", path, err)
+	}
+
+	cfg := &model.Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file at %s: %w", path, err)
+	}
+
+	cfg.SetDefaults()
+
+	return cfg, nil
+}